@@ -0,0 +1,255 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generators
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// kubeBindExportTemplate renders one APIServiceExport per discovered
+// resource into config/kube-bind/, with an inline openAPIV3Schema built by
+// openAPISchemaYAML from the resource's parsed Struct/Field model.
+var kubeBindExportTemplate = template.Must(template.New("kubeBindExport").Parse(`apiVersion: kube-bind.io/v1alpha1
+kind: APIServiceExport
+metadata:
+  name: {{ .Resource }}.{{ .FQGroup }}
+spec:
+  scope: {{ .Scope }}
+  group: {{ .FQGroup }}
+  names:
+    plural: {{ .Resource }}
+    kind: {{ .Kind }}
+  versions:
+  - name: {{ .Version }}
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+{{ .Schema }}
+{{- if .PermissionClaims }}
+  permissionClaims:
+{{- range .PermissionClaims }}
+  - {{ . }}
+{{- end }}
+{{- end }}
+`))
+
+// kubeBindRequestTemplate renders a single APIServiceExportRequest bundling
+// every export generated for a group.
+var kubeBindRequestTemplate = template.Must(template.New("kubeBindRequest").Parse(`apiVersion: kube-bind.io/v1alpha1
+kind: APIServiceExportRequest
+metadata:
+  name: {{ .FQGroup }}
+spec:
+  resources:
+{{- range .Resources }}
+  - group: {{ $.FQGroup }}
+    resource: {{ . }}
+{{- end }}
+`))
+
+// kubeBindExportData is the per-resource template input for an
+// APIServiceExport manifest.
+type kubeBindExportData struct {
+	FQGroup          string
+	Version          string
+	Kind             string
+	Resource         string
+	Scope            string
+	Schema           string
+	PermissionClaims []string
+}
+
+// kubeBindRequestData is the per-group template input for an
+// APIServiceExportRequest manifest.
+type kubeBindRequestData struct {
+	FQGroup   string
+	Resources []string
+}
+
+// WriteKubeBindManifests emits one APIServiceExport per discovered
+// APIResource and an APIServiceExportRequest bundling each group's exports
+// into config/kube-bind/, giving operator authors a one-command path to
+// publish their aggregated APIs as kube-bind service providers.
+func (b *APIsBuilder) WriteKubeBindManifests(outputBase string) error {
+	dir := filepath.Join(outputBase, "config", "kube-bind")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	groups := []string{}
+	for group := range b.APIs.Groups {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	for _, group := range groups {
+		apiGroup := b.APIs.Groups[group]
+		fqGroup := group + "." + apiGroup.Domain
+
+		versions := []string{}
+		for version := range apiGroup.Versions {
+			versions = append(versions, version)
+		}
+		sort.Strings(versions)
+
+		resourceNames := []string{}
+		for _, version := range versions {
+			kinds := []string{}
+			for kind := range apiGroup.Versions[version].Resources {
+				kinds = append(kinds, kind)
+			}
+			sort.Strings(kinds)
+
+			for _, kind := range kinds {
+				resource := apiGroup.Versions[version].Resources[kind]
+
+				scope := "Namespaced"
+				if resource.NonNamespaced {
+					scope = "Cluster"
+				}
+
+				if err := writeYAML(
+					filepath.Join(dir, group+"_"+version+"_"+resource.Resource+".yaml"),
+					kubeBindExportTemplate,
+					kubeBindExportData{
+						FQGroup:          fqGroup,
+						Version:          version,
+						Kind:             resource.Kind,
+						Resource:         resource.Resource,
+						Scope:            scope,
+						Schema:           openAPISchemaYAML(apiGroup, resource.Kind, "        "),
+						PermissionClaims: resource.PermissionClaims,
+					},
+				); err != nil {
+					return err
+				}
+
+				resourceNames = append(resourceNames, resource.Resource)
+			}
+		}
+
+		if err := writeYAML(
+			filepath.Join(dir, group+"-request.yaml"),
+			kubeBindRequestTemplate,
+			kubeBindRequestData{FQGroup: fqGroup, Resources: resourceNames},
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// openAPISchemaYAML renders an inline openAPIV3Schema for kind, indented by
+// indent, reusing the same Struct/Field model ParseStructs already built for
+// deepcopy generation rather than re-deriving field information from
+// scratch.  Each field's type is mapped to its openAPIV3Schema equivalent by
+// openAPIPropertyYAML so the export actually matches the resource's shape.
+func openAPISchemaYAML(apiGroup *APIGroup, kind string, indent string) string {
+	lines := []string{indent + "type: object"}
+
+	var match *Struct
+	for _, s := range apiGroup.Structs {
+		if s.Name == kind {
+			match = s
+			break
+		}
+	}
+	if match == nil || len(match.Fields) == 0 {
+		return strings.Join(lines, "\n")
+	}
+
+	fieldsByProperty := map[string]*Field{}
+	names := []string{}
+	for _, f := range match.Fields {
+		if len(f.Name) == 0 {
+			// Embedded fields don't contribute a JSON property of their own.
+			continue
+		}
+		property := strings.ToLower(f.Name[:1]) + f.Name[1:]
+		fieldsByProperty[property] = f
+		names = append(names, property)
+	}
+	if len(names) == 0 {
+		return strings.Join(lines, "\n")
+	}
+	sort.Strings(names)
+
+	lines = append(lines, indent+"properties:")
+	for _, property := range names {
+		lines = append(lines, indent+"  "+property+":")
+		lines = append(lines, openAPIPropertyYAML(fieldsByProperty[property].UnversionedType, indent+"    ")...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// openAPIPropertyYAML maps a field's Go type to its openAPIV3Schema
+// equivalent, indented by indent: slices become "type: array" with an
+// "items" schema for the element type, maps become "type: object" with an
+// "additionalProperties" schema for the value type, and Go's scalar kinds
+// become their matching OpenAPI scalar type.  Anything else - a nested
+// struct, or an imported type like metav1.ObjectMeta - is rendered as a
+// free-form object, since the generator doesn't have that type's own field
+// list to recurse into.
+func openAPIPropertyYAML(goType string, indent string) []string {
+	goType = strings.TrimPrefix(goType, "*")
+
+	if goType == "[]byte" {
+		// encoding/json marshals []byte as a base64 string, not an array.
+		return []string{indent + "type: string", indent + "format: byte"}
+	}
+	if elem := strings.TrimPrefix(goType, "[]"); elem != goType {
+		lines := []string{indent + "type: array", indent + "items:"}
+		return append(lines, openAPIPropertyYAML(elem, indent+"  ")...)
+	}
+	if strings.HasPrefix(goType, "map[") {
+		if end := strings.Index(goType, "]"); end >= 0 {
+			lines := []string{indent + "type: object", indent + "additionalProperties:"}
+			return append(lines, openAPIPropertyYAML(goType[end+1:], indent+"  ")...)
+		}
+	}
+
+	switch goType {
+	case "string":
+		return []string{indent + "type: string"}
+	case "bool":
+		return []string{indent + "type: boolean"}
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return []string{indent + "type: integer"}
+	case "float32", "float64":
+		return []string{indent + "type: number"}
+	default:
+		return []string{indent + "type: object", indent + "x-kubernetes-preserve-unknown-fields: true"}
+	}
+}
+
+// writeYAML renders tmpl with data and writes it to path as-is - unlike Go
+// source, YAML manifests aren't passed through gofmt.
+func writeYAML(path string, tmpl *template.Template, data interface{}) error {
+	b := &bytes.Buffer{}
+	if err := tmpl.Execute(b, data); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b.Bytes(), 0644)
+}
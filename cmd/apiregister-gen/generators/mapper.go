@@ -0,0 +1,132 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generators
+
+import (
+	"path/filepath"
+	"sort"
+	"text/template"
+)
+
+// mapperTemplate renders pkg/apis/mapper.go - a meta.MultiRESTMapper
+// composed of one meta.DefaultRESTMapper per discovered group, so generated
+// clients and admission plugins can resolve GVR<->GVK without every project
+// reimplementing the mapper.
+var mapperTemplate = template.Must(template.New("mapper").Parse(`
+// RESTMapper resolves GroupVersionResource<->GroupVersionKind for every
+// resource discovered across this project's API groups, most preferred
+// version first.
+var RESTMapper meta.MultiRESTMapper
+
+func init() {
+{{ range $g := .Groups }}	{{ $g.Var }}Mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{
+{{ range $g.Versions }}		{Group: "{{ $g.FQGroup }}", Version: "{{ . }}"},
+{{ end }}	})
+{{ range $g.Resources }}	{{ $g.Var }}Mapper.Add(schema.GroupVersionKind{Group: "{{ .FQGroup }}", Version: "{{ .Version }}", Kind: "{{ .Kind }}"}, {{ .Scope }})
+{{ end }}	RESTMapper = append(RESTMapper, {{ $g.Var }}Mapper)
+{{ end }}}
+`))
+
+// mapperResource is a single APIResource's input to the mapper template.
+type mapperResource struct {
+	FQGroup string
+	Version string
+	Kind    string
+	Scope   string
+}
+
+// mapperGroup is a single group's input to the mapper template, with its
+// versions already ordered most-preferred-first.
+type mapperGroup struct {
+	Var       string
+	FQGroup   string
+	Versions  []string
+	Resources []mapperResource
+}
+
+// mapperData is the top level template input for pkg/apis/mapper.go.
+type mapperData struct {
+	Groups []mapperGroup
+}
+
+// WriteMapperFile emits pkg/apis/mapper.go: one meta.DefaultRESTMapper per
+// discovered group - versions ordered by Priority, GA preferred over beta
+// over alpha unless overridden by +versionPriority - composed into a single
+// meta.MultiRESTMapper, with each resource registered RESTScopeNamespace or
+// RESTScopeRoot based on its NonNamespaced flag.
+func (b *APIsBuilder) WriteMapperFile(outputBase string) error {
+	data := mapperData{}
+
+	groups := []string{}
+	for group := range b.APIs.Groups {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	for _, group := range groups {
+		apiGroup := b.APIs.Groups[group]
+		fqGroup := group + "." + apiGroup.Domain
+
+		versionNames := []string{}
+		for version := range apiGroup.Versions {
+			versionNames = append(versionNames, version)
+		}
+		sort.Slice(versionNames, func(i, j int) bool {
+			vi, vj := apiGroup.Versions[versionNames[i]], apiGroup.Versions[versionNames[j]]
+			if vi.Priority != vj.Priority {
+				return vi.Priority > vj.Priority
+			}
+			return versionNames[i] < versionNames[j]
+		})
+
+		mg := mapperGroup{Var: group, FQGroup: fqGroup, Versions: versionNames}
+		for _, version := range versionNames {
+			kinds := []string{}
+			for kind := range apiGroup.Versions[version].Resources {
+				kinds = append(kinds, kind)
+			}
+			sort.Strings(kinds)
+
+			for _, kind := range kinds {
+				resource := apiGroup.Versions[version].Resources[kind]
+				scope := "meta.RESTScopeNamespace"
+				if resource.NonNamespaced {
+					scope = "meta.RESTScopeRoot"
+				}
+				mg.Resources = append(mg.Resources, mapperResource{
+					FQGroup: fqGroup,
+					Version: version,
+					Kind:    kind,
+					Scope:   scope,
+				})
+			}
+		}
+
+		data.Groups = append(data.Groups, mg)
+	}
+
+	return writeTemplate(
+		filepath.Join(outputBase, "pkg", "apis", "mapper.go"),
+		"apis",
+		[]importSpec{
+			{Path: "k8s.io/apimachinery/pkg/api/meta"},
+			{Path: "k8s.io/apimachinery/pkg/runtime/schema"},
+		},
+		mapperTemplate,
+		data,
+	)
+}
@@ -21,6 +21,8 @@ import (
 	"log"
 	"path"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -100,6 +102,11 @@ type APIVersion struct {
 	Resources map[string]*APIResource
 	// Pkg is the Package object from code-gen
 	Pkg *types.Package
+	// Priority orders this version relative to its siblings when building a
+	// RESTMapper - higher values are preferred.  Populated from the
+	// "+versionPriority=" tag on the version's doc.go, falling back to
+	// Kubernetes-style GA > betaN > alphaN ordering.
+	Priority int
 }
 
 type APIResource struct {
@@ -127,6 +134,10 @@ type APIResource struct {
 	StatusStrategy string
 	// NonNamespaced indicates that the resource kind is non namespaced
 	NonNamespaced bool
+	// PermissionClaims is the list of values from "+permissionClaims=" tags
+	// on the resource's type, used when generating kube-bind
+	// APIServiceExport manifests.
+	PermissionClaims []string
 }
 
 type APISubresource struct {
@@ -147,6 +158,15 @@ type APISubresource struct {
 	// Path is the subresource path - e.g. scale
 	Path string
 
+	// RequestGroup is the group the Request type is defined in, from the
+	// "request-gvk=<group>/<version>/<Kind>" sub-tag on "+subresource=".
+	// Defaults to the parent resource's Group when not set, i.e. the common
+	// case of a same-group subresource.
+	RequestGroup string
+	// RequestVersion is the version the Request type is defined in, from the
+	// "request-gvk=" sub-tag.  Defaults to the parent resource's Version.
+	RequestVersion string
+
 	// ImportPackage is the import statement that must appear for the Request
 	ImportPackage string
 
@@ -176,7 +196,24 @@ type APIsBuilder struct {
 	Groups                map[string]types.Package
 }
 
-func NewAPIsBuilder(context *generator.Context, arguments *args.GeneratorArgs) *APIsBuilder {
+// GenRegister, when true, additionally emits pkg/apis/<group>/register.go
+// and the aggregated pkg/apis/scheme.go.  Off by default so existing
+// projects' codegen runs are unaffected until a user opts in.
+var GenRegister bool
+
+// GenMapper, when true, additionally emits the priority-aware
+// pkg/apis/mapper.go RESTMapper.  Off by default.
+var GenMapper bool
+
+// GenKubeBindManifests, when true, additionally emits the
+// config/kube-bind/ APIServiceExport manifests.  Off by default.
+var GenKubeBindManifests bool
+
+// GenCrossGroupConversions, when true, additionally emits the cross-group
+// subresource conversion wiring.  Off by default.
+var GenCrossGroupConversions bool
+
+func NewAPIsBuilder(context *generator.Context, arguments *args.GeneratorArgs) (*APIsBuilder, error) {
 	b := &APIsBuilder{
 		context:   context,
 		arguments: arguments,
@@ -188,7 +225,31 @@ func NewAPIsBuilder(context *generator.Context, arguments *args.GeneratorArgs) *
 	b.ParseControllers()
 	b.ParseAPIs()
 
-	return b
+	if GenRegister {
+		if err := b.WriteRegisterFiles(b.arguments.OutputBase); err != nil {
+			return nil, errors.Wrap(err, "failed to write group register files")
+		}
+	}
+	if GenMapper {
+		if err := b.WriteMapperFile(b.arguments.OutputBase); err != nil {
+			return nil, errors.Wrap(err, "failed to write RESTMapper file")
+		}
+	}
+	if GenKubeBindManifests {
+		if err := b.WriteKubeBindManifests(b.arguments.OutputBase); err != nil {
+			return nil, errors.Wrap(err, "failed to write kube-bind manifests")
+		}
+	}
+	if GenCrossGroupConversions {
+		if !GenRegister {
+			return nil, errors.New("GenCrossGroupConversions requires GenRegister: the cross-group conversion wiring registers against the localSchemeBuilder register.go generates")
+		}
+		if err := b.WriteCrossGroupConversions(b.arguments.OutputBase); err != nil {
+			return nil, errors.Wrap(err, "failed to write cross-group conversion wiring")
+		}
+	}
+
+	return b, nil
 }
 
 func (b *APIsBuilder) ParseControllers() {
@@ -228,17 +289,18 @@ func (b *APIsBuilder) ParseAPIs() {
 			}
 			for kind, resource := range kindMap {
 				apiResource := &APIResource{
-					Domain:         resource.Domain,
-					Version:        resource.Version,
-					Group:          resource.Group,
-					Resource:       resource.Resource,
-					Type:           resource.Type,
-					REST:           resource.REST,
-					Kind:           resource.Kind,
-					Subresources:   resource.Subresources,
-					StatusStrategy: resource.StatusStrategy,
-					Strategy:       resource.Strategy,
-					NonNamespaced:  resource.NonNamespaced,
+					Domain:           resource.Domain,
+					Version:          resource.Version,
+					Group:            resource.Group,
+					Resource:         resource.Resource,
+					Type:             resource.Type,
+					REST:             resource.REST,
+					Kind:             resource.Kind,
+					Subresources:     resource.Subresources,
+					StatusStrategy:   resource.StatusStrategy,
+					Strategy:         resource.Strategy,
+					NonNamespaced:    resource.NonNamespaced,
+					PermissionClaims: resource.PermissionClaims,
 				}
 				apiVersion.Resources[kind] = apiResource
 				// Set the package for the api version
@@ -250,6 +312,7 @@ func (b *APIsBuilder) ParseAPIs() {
 				apiGroup.UnversionedResources[kind] = apiResource
 			}
 
+			apiVersion.Priority = b.ParseVersionPriority(apiVersion)
 			apiGroup.Versions[version] = apiVersion
 		}
 		b.ParseStructs(apiGroup)
@@ -299,6 +362,7 @@ func (b *APIsBuilder) ParseIndex() {
 		r.REST = rt.REST
 
 		r.Strategy = rt.Strategy
+		r.PermissionClaims = b.GetPermissionClaims(c)
 
 		// If not defined, default the strategy to the group strategy for backwards compatibility
 		if len(r.Strategy) == 0 {
@@ -346,16 +410,27 @@ func (b *APIsBuilder) GetSubresources(c *APIResource) map[string]*APISubresource
 		// Parse the values for each subresource
 		tags := ParseSubresourceTag(c, subresource)
 		sr := &APISubresource{
-			Kind:     tags.Kind,
-			Request:  tags.RequestKind,
-			Path:     tags.Path,
-			REST:     tags.REST,
-			Domain:   b.Domain,
-			Version:  c.Version,
-			Resource: c.Resource,
-			Group:    c.Group,
-		}
-		if !b.IsInPackage(tags) {
+			Kind:           tags.Kind,
+			Request:        tags.RequestKind,
+			Path:           tags.Path,
+			REST:           tags.REST,
+			Domain:         b.Domain,
+			Version:        c.Version,
+			Resource:       c.Resource,
+			Group:          c.Group,
+			RequestGroup:   c.Group,
+			RequestVersion: c.Version,
+		}
+		switch {
+		case len(tags.RequestGroup) > 0:
+			// The Request type lives in a different group's generated apis
+			// package entirely - e.g. autoscaling.Scale on a custom Foo -
+			// rather than merely a different package within the same tree.
+			sr.RequestGroup = tags.RequestGroup
+			sr.RequestVersion = tags.RequestVersion
+			sr.ImportPackage = filepath.Join(b.APIsPkg, tags.RequestGroup, tags.RequestVersion)
+			sr.Request = tags.RequestVersion + "." + tags.RequestKind
+		case !b.IsInPackage(tags):
 			// Out of package Request types require an import and are prefixed with the
 			// package name - e.g. v1.Scale
 			sr.Request, sr.ImportPackage = b.GetNameAndImport(tags)
@@ -454,6 +529,12 @@ type SubresourceTags struct {
 	Kind        string
 	RequestKind string
 	REST        string
+
+	// RequestGroup and RequestVersion come from a "request-gvk=group/version/Kind"
+	// sub-tag, used when the Request type lives in a different group than the
+	// parent resource - e.g. autoscaling.Scale on a custom Foo.
+	RequestGroup   string
+	RequestVersion string
 }
 
 // ParseSubresourceTag parses the tags in a "+subresource=" comment into a SubresourceTags struct
@@ -463,7 +544,7 @@ func ParseSubresourceTag(c *APIResource, tag string) SubresourceTags {
 		kv := strings.Split(elem, "=")
 		if len(kv) != 2 {
 			log.Fatalf("// +subresource: tags must be key value pairs.  Expected "+
-				"keys [request=<requestType>,rest=<restImplType>,path=<subresourcepath>] "+
+				"keys [request=<requestType>,rest=<restImplType>,path=<subresourcepath>,request-gvk=<group>/<version>/<Kind>] "+
 				"Got string: [%s]", tag)
 		}
 		value := kv[1]
@@ -475,6 +556,15 @@ func ParseSubresourceTag(c *APIResource, tag string) SubresourceTags {
 		case "path":
 			// Strip the parent resource
 			result.Path = strings.Replace(value, c.Resource+"/", "", -1)
+		case "request-gvk":
+			parts := strings.Split(value, "/")
+			if len(parts) != 3 {
+				log.Fatalf("// +subresource: request-gvk must be of the form "+
+					"<group>/<version>/<Kind>.  Got string: [%s]", value)
+			}
+			result.RequestGroup = parts[0]
+			result.RequestVersion = parts[1]
+			result.RequestKind = parts[2]
 		}
 	}
 	return result
@@ -515,6 +605,13 @@ func (b *APIsBuilder) GetSubresourceTags(c *types.Type) []string {
 	return comments.GetTags("subresource", ":")
 }
 
+// GetPermissionClaims returns the values of every "+permissionClaims=" tag on
+// c, used to populate a kube-bind APIServiceExport's permission claims.
+func (b *APIsBuilder) GetPermissionClaims(c *types.Type) []string {
+	comments := Comments(c.CommentLines)
+	return comments.GetTags("permissionClaims", "=")
+}
+
 // ParseGroupNames initializes b.GroupNames with the set of all groups
 func (b *APIsBuilder) ParseGroupNames() {
 	b.GroupNames = sets.String{}
@@ -566,6 +663,62 @@ func (b *APIsBuilder) ParseDomain() {
 	}
 }
 
+// ParseVersionPriority returns the RESTMapper priority for v, read from the
+// "// +versionPriority=<n>" comment tag on the version's doc.go if present,
+// falling back to Kubernetes-style GA > betaN > alphaN ordering by
+// descending N so that, absent an explicit override, the most stable version
+// wins.
+func (b *APIsBuilder) ParseVersionPriority(v *APIVersion) int {
+	if v.Pkg != nil {
+		comments := Comments(v.Pkg.Comments)
+		if tag := comments.GetTag("versionPriority", "="); len(tag) > 0 {
+			priority, err := strconv.Atoi(tag)
+			if err != nil {
+				log.Fatalf("+versionPriority=%s for %s is not an integer: %v", tag, v.Version, err)
+			}
+			return priority
+		}
+	}
+	return defaultVersionPriority(v.Version)
+}
+
+// versionPattern parses Kubernetes-style version strings - e.g. v1,
+// v1beta2, v2alpha1 - into their GA/beta/alpha stage and numeric suffix.
+var versionPattern = regexp.MustCompile(`^v(\d+)(?:(alpha|beta)(\d+))?$`)
+
+// defaultVersionPriority ranks version according to the same GA > betaN >
+// alphaN by descending N convention Kubernetes itself uses to order its
+// group versions, so version strings that don't carry an explicit
+// +versionPriority tag still sort sensibly relative to each other.
+func defaultVersionPriority(version string) int {
+	m := versionPattern.FindStringSubmatch(version)
+	if m == nil {
+		// Not a Kubernetes-style version string - give it the lowest priority.
+		return 0
+	}
+
+	major, _ := strconv.Atoi(m[1])
+	stage := m[2]
+	minor := 0
+	if len(m[3]) > 0 {
+		minor, _ = strconv.Atoi(m[3])
+	}
+
+	// Rank by stage first - GA, then beta, then alpha - and within a stage
+	// by major version, then minor version, both higher first.
+	const band = 1000
+	priority := major*band + minor
+	switch stage {
+	case "":
+		priority += band * band * 3
+	case "beta":
+		priority += band * band * 2
+	case "alpha":
+		priority += band * band
+	}
+	return priority
+}
+
 func (b *APIsBuilder) ParseStructs(apigroup *APIGroup) {
 	remaining := []*types.Type{}
 	for _, version := range apigroup.Versions {
@@ -0,0 +1,249 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generators
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/template"
+)
+
+// registerTemplate renders pkg/apis/<group>/register.go - the GroupName
+// constant and per-version SchemeGroupVersion that hand-written projects
+// previously had to maintain themselves after "apiserver-boot init".
+var registerTemplate = template.Must(template.New("register").Parse(`
+// GroupName is the name of the API group for {{ .Group }} resources - e.g. used
+// in the Kind's TypeMeta and in RESTMapper registration.
+const GroupName = "{{ .Group }}.{{ .Domain }}"
+
+{{ range .Versions }}
+// SchemeGroupVersion_{{ . }} is the group version used to register the {{ . }} types.
+var SchemeGroupVersion_{{ . }} = schema.GroupVersion{Group: GroupName, Version: "{{ . }}"}
+{{ end }}
+`))
+
+// versionRegisterTemplate renders pkg/apis/<group>/<version>/register.go -
+// the SchemeBuilder/AddToScheme pair that real Kubernetes API groups hand
+// roll, so the rest of the generated code (including the cross-group
+// conversion wiring) has a localSchemeBuilder to register against.
+var versionRegisterTemplate = template.Must(template.New("versionRegister").Parse(`
+// SchemeGroupVersion is the group version used to register the {{ .Group }}/{{ .Version }} types.
+var SchemeGroupVersion = schema.GroupVersion{Group: "{{ .Group }}.{{ .Domain }}", Version: "{{ .Version }}"}
+
+// Resource takes an unqualified resource and returns a Group qualified GroupResource.
+func Resource(resource string) schema.GroupResource {
+	return SchemeGroupVersion.WithResource(resource).GroupResource()
+}
+
+var (
+	// SchemeBuilder collects the functions that add this version's types to
+	// a scheme.
+	SchemeBuilder      = runtime.NewSchemeBuilder(addKnownTypes)
+	localSchemeBuilder = &SchemeBuilder
+	// AddToScheme adds the {{ .Group }}/{{ .Version }} types to s.
+	AddToScheme = localSchemeBuilder.AddToScheme
+)
+
+// addKnownTypes registers this version's Kinds with scheme.
+func addKnownTypes(scheme *runtime.Scheme) error {
+	scheme.AddKnownTypes(SchemeGroupVersion,
+{{ range .Kinds }}		&{{ . }}{},
+		&{{ . }}List{},
+{{ end }}	)
+	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
+	return nil
+}
+`))
+
+// schemeTemplate renders the aggregated pkg/apis/scheme.go that wires every
+// discovered group/version's AddToScheme into a single runtime.Scheme.
+var schemeTemplate = template.Must(template.New("scheme").Parse(`
+// Scheme is the runtime.Scheme all of this project's API groups are
+// registered against.
+var Scheme = runtime.NewScheme()
+
+// AddToScheme adds every discovered group/version to s.
+func AddToScheme(s *runtime.Scheme) error {
+	for _, add := range []func(*runtime.Scheme) error{
+{{ range .Imports }}		{{ . }}.AddToScheme,
+{{ end }}	} {
+		if err := add(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	if err := AddToScheme(Scheme); err != nil {
+		log.Fatalf("failed to register types with scheme: %v", err)
+	}
+}
+`))
+
+// registerData is the per-group template input for register.go.
+type registerData struct {
+	Group    string
+	Domain   string
+	Versions []string
+}
+
+// versionRegisterData is the per-group/version template input for
+// pkg/apis/<group>/<version>/register.go.
+type versionRegisterData struct {
+	Group   string
+	Domain  string
+	Version string
+	Kinds   []string
+}
+
+// schemeData is the template input for the aggregated scheme.go.
+type schemeData struct {
+	Imports []string
+}
+
+// schemeImportAlias returns the import alias a group/version package is
+// given wherever it's imported alongside its siblings - e.g. scheme.go and
+// the cross-group conversion wiring - so that two different groups sharing
+// a version name (both "v1") don't collide under the same default package
+// name, mirroring the corev1/appsv1-style aliasing client-gen uses for the
+// same reason.
+func schemeImportAlias(group, version string) string {
+	return group + version
+}
+
+// WriteRegisterFiles emits pkg/apis/<group>/register.go for every discovered
+// group, pkg/apis/<group>/<version>/register.go for every version (with the
+// SchemeBuilder/AddToScheme real API groups hand roll), and the aggregated
+// pkg/apis/scheme.go, driven by the b.APIs.Groups index already built by
+// ParseAPIs.
+func (b *APIsBuilder) WriteRegisterFiles(outputBase string) error {
+	groups := []string{}
+	for group := range b.APIs.Groups {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	schemeImports := []importSpec{
+		{Path: "log"},
+		{Path: "k8s.io/apimachinery/pkg/runtime"},
+	}
+	aliases := []string{}
+
+	for _, group := range groups {
+		apiGroup := b.APIs.Groups[group]
+
+		versions := []string{}
+		for version := range apiGroup.Versions {
+			versions = append(versions, version)
+		}
+		sort.Strings(versions)
+
+		if err := writeTemplate(
+			filepath.Join(outputBase, "pkg", "apis", group, "register.go"),
+			group,
+			[]importSpec{{Path: "k8s.io/apimachinery/pkg/runtime/schema"}},
+			registerTemplate,
+			registerData{Group: group, Domain: b.Domain, Versions: versions},
+		); err != nil {
+			return err
+		}
+
+		for _, version := range versions {
+			kinds := []string{}
+			for kind := range apiGroup.Versions[version].Resources {
+				kinds = append(kinds, kind)
+			}
+			sort.Strings(kinds)
+
+			if err := writeTemplate(
+				filepath.Join(outputBase, "pkg", "apis", group, version, "register.go"),
+				version,
+				[]importSpec{
+					{Alias: "metav1", Path: "k8s.io/apimachinery/pkg/apis/meta/v1"},
+					{Path: "k8s.io/apimachinery/pkg/runtime"},
+					{Path: "k8s.io/apimachinery/pkg/runtime/schema"},
+				},
+				versionRegisterTemplate,
+				versionRegisterData{Group: group, Domain: b.Domain, Version: version, Kinds: kinds},
+			); err != nil {
+				return err
+			}
+
+			alias := schemeImportAlias(group, version)
+			aliases = append(aliases, alias)
+			schemeImports = append(schemeImports, importSpec{Alias: alias, Path: filepath.Join(b.APIsPkg, group, version)})
+		}
+	}
+
+	return writeTemplate(
+		filepath.Join(outputBase, "pkg", "apis", "scheme.go"),
+		"apis",
+		schemeImports,
+		schemeTemplate,
+		schemeData{Imports: aliases},
+	)
+}
+
+// importSpec is a single import line for a generated file - Alias may be
+// empty when the package's default name already matches what the template
+// references it as.
+type importSpec struct {
+	Alias string
+	Path  string
+}
+
+// writeTemplate renders tmpl with data behind an import block built from
+// imports, gofmt's the result, and writes it to path, creating parent
+// directories as needed.
+func writeTemplate(path, pkg string, imports []importSpec, tmpl *template.Template, data interface{}) error {
+	b := &bytes.Buffer{}
+	b.WriteString("package " + pkg + "\n\n")
+
+	if len(imports) > 0 {
+		b.WriteString("import (\n")
+		for _, imp := range imports {
+			if len(imp.Alias) > 0 {
+				fmt.Fprintf(b, "\t%s %q\n", imp.Alias, imp.Path)
+			} else {
+				fmt.Fprintf(b, "\t%q\n", imp.Path)
+			}
+		}
+		b.WriteString(")\n\n")
+	}
+
+	if err := tmpl.Execute(b, data); err != nil {
+		return err
+	}
+
+	formatted, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Printf("warning: could not gofmt %s: %v", path, err)
+		formatted = b.Bytes()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, formatted, 0644)
+}
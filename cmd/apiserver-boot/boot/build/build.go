@@ -0,0 +1,36 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var buildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Builds the source",
+	Long:  `Builds the source`,
+}
+
+// AddBuild registers the "build" command, along with its "executables" and
+// "images" subcommands, with parent.
+func AddBuild(parent *cobra.Command) {
+	parent.AddCommand(buildCmd)
+
+	AddBuildExecutables(buildCmd)
+	AddBuildImages(buildCmd)
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/spf13/cobra"
+)
+
+var imageRepo string
+var imageTag string
+var imagePush bool
+var imagesBaseDir string
+
+var createBuildImagesCmd = &cobra.Command{
+	Use:   "images",
+	Short: "Packages the cross-compiled executables into multi-arch OCI images",
+	Long: `Packages the binaries produced by "apiserver-boot build executables" into
+distroless-based OCI images, without requiring a local Docker daemon, and
+assembles them into a manifest list spanning every built platform.`,
+	Example: `# Cross-compile for a platform matrix, then package the results as images
+apiserver-boot build executables --platforms linux/amd64,linux/arm64
+apiserver-boot build images --image-repo gcr.io/example/apiserver --tag v1.2.3 --push`,
+	Run: RunBuildImages,
+}
+
+func AddBuildImages(cmd *cobra.Command) {
+	cmd.AddCommand(createBuildImagesCmd)
+
+	createBuildImagesCmd.Flags().StringVar(&imageRepo, "image-repo", "", "the image repository to tag and push images to - e.g. gcr.io/example/apiserver")
+	createBuildImagesCmd.Flags().StringVar(&imageTag, "tag", "latest", "the tag to apply to the produced images")
+	createBuildImagesCmd.Flags().BoolVar(&imagePush, "push", false, "if true, push the images and manifest lists to --image-repo using the credentials in ~/.docker/config.json")
+	createBuildImagesCmd.Flags().StringVar(&imagesBaseDir, "base-image-dir", filepath.Join("config", "images"), "directory containing the distroless base image tarballs, keyed by <goos>_<goarch>.tar")
+}
+
+// RunBuildImages packages the executables previously cross-compiled by
+// "build executables" into a manifest list of OCI images - one image per
+// <goos>_<goarch> directory found under outputdir ("bin"; this command has
+// no --output flag of its own) - and optionally pushes it to --image-repo.
+func RunBuildImages(cmd *cobra.Command, args []string) {
+	if len(imageRepo) == 0 {
+		log.Fatal("--image-repo is required")
+	}
+
+	platforms := discoverBuiltPlatforms()
+	if len(platforms) == 0 {
+		log.Fatalf("no cross-compiled binaries found under %s; run \"apiserver-boot build executables --platforms ...\" first", outputdir)
+	}
+
+	for _, b := range binaries {
+		repo := fmt.Sprintf("%s/%s", imageRepo, b.name)
+		index := mutate.IndexMediaType(empty.Index, "application/vnd.docker.distribution.manifest.list.v2+json")
+
+		for _, p := range platforms {
+			img, err := imageForPlatform(p, b)
+			if err != nil {
+				log.Fatalf("building image for %s/%s %s: %v", p.goos, p.goarch, b.name, err)
+			}
+
+			index = mutate.AppendManifests(index, mutate.IndexAddendum{
+				Add: img,
+				Descriptor: v1.Descriptor{
+					Platform: &v1.Platform{OS: p.goos, Architecture: p.goarch},
+				},
+			})
+
+			if err := writeImageMetadata(p, b, img); err != nil {
+				log.Fatalf("writing digest/sbom for %s/%s %s: %v", p.goos, p.goarch, b.name, err)
+			}
+		}
+
+		ref, err := name.NewTag(fmt.Sprintf("%s:%s", repo, imageTag))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if imagePush {
+			log.Printf("pushing manifest list for %s", ref)
+			if err := remote.WriteIndex(ref, index, remote.WithAuthFromKeychain(authn.DefaultKeychain)); err != nil {
+				log.Fatalf("pushing %s: %v", ref, err)
+			}
+		}
+
+		if err := rewriteImageManifests(repo, imageTag); err != nil {
+			log.Fatalf("rewriting config manifests for %s: %v", repo, err)
+		}
+	}
+}
+
+// discoverBuiltPlatforms inspects outputdir for the <goos>_<goarch>
+// directories written by "build executables --platforms ...".
+func discoverBuiltPlatforms() []platform {
+	entries, err := ioutil.ReadDir(outputdir)
+	if err != nil {
+		return nil
+	}
+
+	platforms := []platform{}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		parts := strings.SplitN(e.Name(), "_", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		platforms = append(platforms, platform{goos: parts[0], goarch: parts[1]})
+	}
+	return platforms
+}
+
+// platformBinaryDir returns the directory discoverBuiltPlatforms found p's
+// binaries under - outputdir/<goos>_<goarch>/.  It deliberately doesn't
+// reuse build_executables.go's buildOutputDir: that helper keys off
+// platformsFlag, which is only ever set by the "build executables"
+// invocation, never by this one, so it would always resolve to the flat
+// outputdir here regardless of how the binaries were actually laid out.
+func platformBinaryDir(p platform) string {
+	return filepath.Join(outputdir, fmt.Sprintf("%s_%s", p.goos, p.goarch))
+}
+
+// imageForPlatform layers the platform's executable onto the distroless base
+// image for that platform.
+func imageForPlatform(p platform, b binary) (v1.Image, error) {
+	base, err := tarball.ImageFromPath(filepath.Join(imagesBaseDir, fmt.Sprintf("%s_%s.tar", p.goos, p.goarch)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	layer, err := tarball.LayerFromFile(filepath.Join(platformBinaryDir(p), b.name))
+	if err != nil {
+		return nil, err
+	}
+
+	return mutate.AppendLayers(base, layer)
+}
+
+// writeImageMetadata emits the per-image digest and SBOM files alongside the
+// built binary so release manifests can be pinned to an exact image.
+func writeImageMetadata(p platform, b binary, img v1.Image) error {
+	dir := platformBinaryDir(p)
+
+	digest, err := img.Digest()
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, b.name+".digest"), []byte(digest.String()), 0644); err != nil {
+		return err
+	}
+
+	sbom, err := sbomFor(p, b, digest)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, b.name+".sbom.json"), sbom, 0644)
+}
+
+// sbomFor produces a minimal SBOM describing the binary packaged into the
+// image for a given platform.
+func sbomFor(p platform, b binary, digest v1.Hash) ([]byte, error) {
+	return json.MarshalIndent(map[string]interface{}{
+		"binary":   b.name,
+		"platform": fmt.Sprintf("%s/%s", p.goos, p.goarch),
+		"digest":   digest.String(),
+	}, "", "  ")
+}
+
+// rewriteImageManifests rewrites the config/ manifests in place so their
+// container image references point at repo:tag, similar to how ko integrates
+// with kubebuilder-style projects.
+func rewriteImageManifests(repo, tag string) error {
+	return filepath.Walk("config", func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".yaml") {
+			return err
+		}
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rewritten := rewriteImageRefs(string(contents), repo, tag)
+		if rewritten == string(contents) {
+			return nil
+		}
+		return ioutil.WriteFile(path, []byte(rewritten), info.Mode())
+	})
+}
+
+// rewriteImageRefs replaces "image: <repo>" placeholders in a manifest with
+// the fully qualified repo:tag reference.
+func rewriteImageRefs(contents, repo, tag string) string {
+	return strings.Replace(contents, fmt.Sprintf("image: %s\n", repo), fmt.Sprintf("image: %s:%s\n", repo, tag), -1)
+}